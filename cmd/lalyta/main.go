@@ -1,41 +1,99 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/tidwall/buntdb"
 
 	"github.com/thinkofher/lalyta/pkg/api"
+	"github.com/thinkofher/lalyta/pkg/config"
+	"github.com/thinkofher/lalyta/pkg/service/auth"
+	"github.com/thinkofher/lalyta/pkg/service/janitor"
 	"github.com/thinkofher/lalyta/pkg/service/params"
+	"github.com/thinkofher/lalyta/pkg/service/ratelimit"
 	"github.com/thinkofher/lalyta/pkg/storage"
 )
 
+const version = "1.1.13"
+
 func run() error {
-	bunt, err := buntdb.Open("lalyta.db")
+	configPath := flag.String("config", "", "path to a TOML config file (optional, env vars override it)")
+	rateLimit := flag.Float64("rate-limit", 0, "requests per second allowed per sync id and client IP (0 disables)")
+	rateBurst := flag.Int("rate-burst", 5, "burst size for the rate limiter")
+	retentionDays := flag.Int("retention-days", 0, "delete syncs untouched for this many days (0 = never)")
+	retentionDryRun := flag.Bool("dry-run", false, "log what the retention janitor would delete instead of deleting it")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		return fmt.Errorf("buntdb.Open: %w", err)
+		return fmt.Errorf("config.Load: %w", err)
 	}
-	defer bunt.Close()
+	log.Printf("effective config: %s", cfg)
 
-	buntStorage := storage.New(bunt)
+	store, err := storage.New(storage.Config{
+		Driver: storage.Driver(cfg.DB.Driver),
+		DSN:    cfg.DB.DSN,
+	})
+	if err != nil {
+		return fmt.Errorf("storage.New: %w", err)
+	}
+	defer store.Close()
 
 	chiParams := new(params.Chi)
+	apiCfg := api.Config{
+		MaxSyncSize:        cfg.Service.MaxSyncSize,
+		Status:             cfg.Service.Status,
+		DailyNewSyncsLimit: cfg.Service.DailyNewSyncsLimit,
+		TrustProxy:         cfg.Service.TrustProxy,
+	}
+
+	authCfg := auth.Config{
+		Mode:   auth.Mode(cfg.Auth.Mode),
+		Token:  cfg.Auth.Token,
+		Secret: []byte(cfg.Auth.JWTSecret),
+	}
+	authMW := auth.Middleware(authCfg, chiParams)
+	bootstrapAuthMW := auth.BootstrapMiddleware(authCfg)
+
+	var issuer api.TokenIssuer
+	if authCfg.Mode == auth.ModeJWT {
+		issuer = auth.NewIssuer(authCfg)
+	}
+
+	limiter := ratelimit.New(ratelimit.Config{
+		RatePerSecond: *rateLimit,
+		Burst:         *rateBurst,
+		TrustProxy:    cfg.Service.TrustProxy,
+	})
+	rateLimitMW := limiter.Middleware(chiParams)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
-	r.Get("/info", api.Info("PL", "Hello World!", "1.1.13"))
-	r.Post("/bookmarks", api.CreateBookmarks(buntStorage))
-	r.Get("/bookmarks/{id}", api.Bookmarks(buntStorage, chiParams))
-	r.Put("/bookmarks/{id}", api.UpdateBookmarks(buntStorage, chiParams))
-	r.Get("/bookmarks/{id}/lastUpdated", api.LastUpdated(buntStorage, chiParams))
-	r.Get("/bookmarks/{id}/version", api.Version(buntStorage, chiParams))
-
-	log.Println("Starting server at 0.0.0.0:8080")
-	return http.ListenAndServe("0.0.0.0:8080", r)
+	r.Get("/info", api.Info(apiCfg, cfg.Service.Location, cfg.Service.Message, version))
+	r.With(bootstrapAuthMW).Post("/bookmarks", api.CreateBookmarks(store, apiCfg, issuer))
+	r.With(authMW, rateLimitMW).Get("/bookmarks/{id}", api.Bookmarks(store, chiParams))
+	r.With(authMW, rateLimitMW).Put("/bookmarks/{id}", api.UpdateBookmarks(store, chiParams, apiCfg))
+	r.Get("/bookmarks/{id}/lastUpdated", api.LastUpdated(store, chiParams))
+	r.Get("/bookmarks/{id}/version", api.Version(store, chiParams))
+	r.With(authMW).Delete("/bookmarks/{id}", api.DeleteBookmarks(store, chiParams))
+
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	go janitor.Run(janitorCtx, store, janitor.Config{
+		RetentionDays: *retentionDays,
+		DryRun:        *retentionDryRun,
+	})
+
+	log.Printf("Starting server at %s", cfg.ListenAddr)
+	if cfg.TLS.CertFile != "" && cfg.TLS.KeyFile != "" {
+		return http.ListenAndServeTLS(cfg.ListenAddr, cfg.TLS.CertFile, cfg.TLS.KeyFile, r)
+	}
+	return http.ListenAndServe(cfg.ListenAddr, r)
 }
 
 func main() {
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"time"
 
@@ -26,18 +27,34 @@ import (
 	"github.com/thinkofher/lalyta/pkg/service/gen"
 )
 
+// readBody reads the full request body, rejecting it with
+// ErrSyncDataLimitExceeded when it exceeds cfg.MaxSyncSize.
+func readBody(w http.ResponseWriter, r *http.Request, cfg Config) ([]byte, bool) {
+	reader := r.Body
+	if cfg.MaxSyncSize > 0 {
+		reader = http.MaxBytesReader(w, r.Body, cfg.MaxSyncSize)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		WriteError(w, ErrSyncDataLimitExceeded)
+		return nil, false
+	}
+	return body, true
+}
+
 // Info retrieves information describing the xBrowserSync service.
 //
-//  GET /info
+//	GET /info
 //
 // Response example:
 //
-//  {
-//    "maxSyncSize": 204800,
-//    "message": "",
-//    "status": 1,
-//    "version": "1.1.13"
-//  }
+//	{
+//	  "maxSyncSize": 204800,
+//	  "message": "",
+//	  "status": 1,
+//	  "version": "1.1.13"
+//	}
 //
 // * Status ("status", int): current service status code. 1 = Online; 2 = Offline;
 // 3 = Not accepting new syncs.
@@ -48,7 +65,7 @@ import (
 //
 // * Maximum sync size ("maxSyncSize", int): maximum sync size (in bytes)
 // allowed by the service.
-func Info(location, msg, version string) http.HandlerFunc {
+func Info(cfg Config, location, msg, version string) http.HandlerFunc {
 	type response struct {
 		MaxSyncSize int64  `json:"maxSyncSize"`
 		Message     string `json:"message"`
@@ -57,9 +74,9 @@ func Info(location, msg, version string) http.HandlerFunc {
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gores.JSON(w, http.StatusOK, &response{
-			MaxSyncSize: 204800,
+			MaxSyncSize: cfg.MaxSyncSize,
 			Message:     msg,
-			Status:      1,
+			Status:      cfg.Status,
 			Version:     version,
 		})
 	})
@@ -77,6 +94,17 @@ type BookmarksStorage interface {
 	// GetBookmarks retrieves encrypted Bookmarks with given id
 	// from database.
 	GetBookmarks(ctx context.Context, id string) (*models.Bookmarks, error)
+
+	// DeleteBookmarks removes the Bookmarks with given id from database.
+	// It is a no-op (not an error) if no such Bookmarks exist.
+	DeleteBookmarks(ctx context.Context, id string) error
+
+	// ListBookmarks retrieves every Bookmarks currently in database, for
+	// use by maintenance tasks such as retention sweeps. Since those
+	// tasks only need identifiers and timestamps, implementations may
+	// leave Bookmarks and Version unset rather than loading the full
+	// encrypted payload.
+	ListBookmarks(ctx context.Context) ([]models.Bookmarks, error)
 }
 
 // QueryParameters help to determine specific bookmarks.
@@ -90,24 +118,31 @@ type QueryParameters interface {
 // there is no bookmarks with given ID in storage.
 var ErrBookmarksNotFound = errors.New("bookmarks with given id has been not found")
 
+// TokenIssuer optionally issues a bearer token scoped to a single sync
+// ID, for deployments that run with per-sync authentication enabled. A
+// nil TokenIssuer disables this behaviour.
+type TokenIssuer interface {
+	IssueToken(id string) (string, error)
+}
+
 // CreateBookmarks creates a new (empty) bookmark sync and returns
 // the corresponding ID.
 //
-//  POST /bookmarks
+//	POST /bookmarks
 //
 // Post body example:
 //
-//  {
-//    "version": "1.0.0"
-//  }
+//	{
+//	  "version": "1.0.0"
+//	}
 //
 // Response example:
 //
-//  {
-//    "id": "52758cb942814faa9ab255208025ae59",
-//    "lastUpdated": "2016-07-06T12:43:16.866Z",
-//    "version": "1.0.0"
-//  }
+//	{
+//	  "id": "52758cb942814faa9ab255208025ae59",
+//	  "lastUpdated": "2016-07-06T12:43:16.866Z",
+//	  "version": "1.0.0"
+//	}
 //
 // * ID ("id", string): 32 character alphanumeric sync ID.
 //
@@ -116,7 +151,7 @@ var ErrBookmarksNotFound = errors.New("bookmarks with given id has been not foun
 //
 // * Version ("version", version): version number of the xBrowserSync client
 // used to create the sync.
-func CreateBookmarks(storage BookmarksStorage) http.HandlerFunc {
+func CreateBookmarks(storage BookmarksStorage, cfg Config, issuer TokenIssuer) http.HandlerFunc {
 	type payload struct {
 		Version string `json:"version"`
 	}
@@ -124,40 +159,80 @@ func CreateBookmarks(storage BookmarksStorage) http.HandlerFunc {
 		ID          string    `json:"id"`
 		LastUpdated time.Time `json:"lastUpdated"`
 		Version     string    `json:"version"`
+		Token       string    `json:"token,omitempty"`
 	}
+
+	newSyncs := newDailySyncCounter()
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
+
+		if cfg.Status == StatusNotAcceptingNewSyncs {
+			WriteError(w, ErrNewSyncsForbidden)
+			return
+		}
+
+		body, ok := readBody(w, r, cfg)
+		if !ok {
+			return
+		}
+
 		p := new(payload)
+		if err := json.Unmarshal(body, p); err != nil {
+			WriteError(w, ErrUnspecified)
+			return
+		}
+
+		if p.Version == "" {
+			WriteError(w, ErrRequiredDataNotFound)
+			return
+		}
 
-		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusInternalServerError)
+		// Only a request that has passed validation counts against the
+		// daily quota. The slot is reserved here but given back below if
+		// the sync doesn't actually get created, so an internal failure
+		// doesn't burn the client's quota either.
+		ip := clientIP(r, cfg.TrustProxy)
+		if !newSyncs.allow(ip, cfg.DailyNewSyncsLimit) {
+			WriteError(w, ErrNewSyncsLimitExceeded)
 			return
 		}
 
 		id, err := gen.String(idLength)
 		if err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusInternalServerError)
+			newSyncs.release(ip)
+			WriteError(w, ErrUnspecified)
 			return
 		}
 
+		now := time.Now().UTC()
 		bookmarks := models.Bookmarks{
 			ID:          id,
 			Bookmarks:   "",
-			LastUpdated: time.Now().UTC(),
+			LastUpdated: now,
 			Version:     p.Version,
+			CreatedAt:   now,
 		}
 		if err := storage.SetBookmarks(ctx, bookmarks); err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusInternalServerError)
+			newSyncs.release(ip)
+			WriteError(w, ErrUnspecified)
 			return
 		}
 
+		var token string
+		if issuer != nil {
+			token, err = issuer.IssueToken(bookmarks.ID)
+			if err != nil {
+				WriteError(w, ErrUnspecified)
+				return
+			}
+		}
+
 		gores.JSON(w, http.StatusOK, &response{
 			ID:          bookmarks.ID,
 			LastUpdated: bookmarks.LastUpdated,
 			Version:     bookmarks.Version,
+			Token:       token,
 		})
 	})
 }
@@ -165,7 +240,7 @@ func CreateBookmarks(storage BookmarksStorage) http.HandlerFunc {
 // Bookmarks retrieves the bookmark sync corresponding to the
 // provided sync ID.
 //
-//  GET /bookmarks/{id}
+//	GET /bookmarks/{id}
 //
 // Query params:
 //
@@ -173,11 +248,11 @@ func CreateBookmarks(storage BookmarksStorage) http.HandlerFunc {
 //
 // Response example:
 //
-//  {
-//    "bookmarks": "DWCx6wR9ggPqPRrhU4O4oLN5P09oULX4Xt+ckxswtFNds...",
-//    "lastUpdated": "2016-07-06T12:43:16.866Z",
-//    "version": "1.0.0"
-//  }
+//	{
+//	  "bookmarks": "DWCx6wR9ggPqPRrhU4O4oLN5P09oULX4Xt+ckxswtFNds...",
+//	  "lastUpdated": "2016-07-06T12:43:16.866Z",
+//	  "version": "1.0.0"
+//	}
 //
 // * Bookmarks ("bookmarks", string): encrypted bookmark data salted using
 // secret value.
@@ -197,15 +272,13 @@ func Bookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFun
 		ctx := r.Context()
 		id := params.ID(r)
 		if id == "" {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+			WriteError(w, ErrRequiredDataNotFound)
 			return
 		}
 
 		b, err := storage.GetBookmarks(ctx, id)
 		if err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusNotFound)
+			WriteError(w, ErrSyncDataNotFound)
 			return
 		}
 
@@ -220,7 +293,7 @@ func Bookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFun
 // UpdateBookmarks updates the bookmark sync data corresponding to the
 // provided sync ID with the provided encrypted bookmarks data.
 //
-//  PUT /bookmarks/{id}
+//	PUT /bookmarks/{id}
 //
 // Query params:
 //
@@ -228,10 +301,10 @@ func Bookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFun
 //
 // Post body example:
 //
-//  {
-//    "bookmarks": "DWCx6wR9ggPqPRrhU4O4oLN5P09oULX4Xt+ckxswtFNds...",
-//    "lastUpdated": "2016-07-06T12:43:16.866Z",
-//  }
+//	{
+//	  "bookmarks": "DWCx6wR9ggPqPRrhU4O4oLN5P09oULX4Xt+ckxswtFNds...",
+//	  "lastUpdated": "2016-07-06T12:43:16.866Z",
+//	}
 //
 // * Bookmarks ("bookmarks", string): encrypted bookmark data salted using
 // secret value.
@@ -241,13 +314,13 @@ func Bookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFun
 //
 // Response example:
 //
-//  {
-//    "lastUpdated": "2016-07-06T12:43:16.866Z"
-//  }
+//	{
+//	  "lastUpdated": "2016-07-06T12:43:16.866Z"
+//	}
 //
 // Last updated ("lastUpdated", timestamp as string): last updated timestamp
 // for updated bookmarks.
-func UpdateBookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFunc {
+func UpdateBookmarks(storage BookmarksStorage, params QueryParameters, cfg Config) http.HandlerFunc {
 	type payload struct {
 		Bookmarks   string    `json:"bookmarks"`
 		LastUpdated time.Time `json:"lastUpdated"`
@@ -259,28 +332,29 @@ func UpdateBookmarks(storage BookmarksStorage, params QueryParameters) http.Hand
 		ctx := r.Context()
 		id := params.ID(r)
 		if id == "" {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+			WriteError(w, ErrRequiredDataNotFound)
+			return
+		}
+
+		body, ok := readBody(w, r, cfg)
+		if !ok {
 			return
 		}
 
 		p := new(payload)
-		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+		if err := json.Unmarshal(body, p); err != nil {
+			WriteError(w, ErrUnspecified)
 			return
 		}
 
 		b, err := storage.GetBookmarks(ctx, id)
 		if err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusNotFound)
+			WriteError(w, ErrSyncNotFound)
 			return
 		}
 
 		if p.LastUpdated != b.LastUpdated {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+			WriteError(w, ErrSyncConflict)
 			return
 		}
 
@@ -290,9 +364,10 @@ func UpdateBookmarks(storage BookmarksStorage, params QueryParameters) http.Hand
 			Bookmarks:   p.Bookmarks,
 			LastUpdated: now,
 			Version:     b.Version,
+			CreatedAt:   b.CreatedAt,
 		})
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+			WriteError(w, ErrUnspecified)
 			return
 		}
 
@@ -305,7 +380,7 @@ func UpdateBookmarks(storage BookmarksStorage, params QueryParameters) http.Hand
 // LastUpdated retrieves the bookmark sync last updated timestamp
 // corresponding to the provided sync ID.
 //
-//   GET /bookmarks/{id}/lastUpdated
+//	GET /bookmarks/{id}/lastUpdated
 //
 // Query params:
 //
@@ -313,9 +388,9 @@ func UpdateBookmarks(storage BookmarksStorage, params QueryParameters) http.Hand
 //
 // Response example:
 //
-//  {
-//    "lastUpdated":"2016-07-06T12:43:16.866Z"
-//  }
+//	{
+//	  "lastUpdated":"2016-07-06T12:43:16.866Z"
+//	}
 //
 // * Last updated ("lastUpdated", timestamp as string): last updated
 // timestamp for corresponding bookmarks.
@@ -327,15 +402,13 @@ func LastUpdated(storage BookmarksStorage, params QueryParameters) http.HandlerF
 		ctx := r.Context()
 		id := params.ID(r)
 		if id == "" {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+			WriteError(w, ErrRequiredDataNotFound)
 			return
 		}
 
 		b, err := storage.GetBookmarks(ctx, id)
 		if err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusNotFound)
+			WriteError(w, ErrSyncNotFound)
 			return
 		}
 
@@ -348,7 +421,7 @@ func LastUpdated(storage BookmarksStorage, params QueryParameters) http.HandlerF
 // Version retrieves the bookmark sync version number of the xBrowserSync client
 // used to create the bookmarks sync corresponding to the provided sync ID.
 //
-//  GET /bookmarks/{id}/version
+//	GET /bookmarks/{id}/version
 //
 // Query params:
 //
@@ -356,9 +429,9 @@ func LastUpdated(storage BookmarksStorage, params QueryParameters) http.HandlerF
 //
 // Response example:
 //
-//  {
-//    "version":"1.0.0"
-//  }
+//	{
+//	  "version":"1.0.0"
+//	}
 //
 // Version ("version", string): version number of the xBrowserSync client
 // used to create the sync.
@@ -370,15 +443,13 @@ func Version(storage BookmarksStorage, params QueryParameters) http.HandlerFunc
 		ctx := r.Context()
 		id := params.ID(r)
 		if id == "" {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusBadRequest)
+			WriteError(w, ErrRequiredDataNotFound)
 			return
 		}
 
 		b, err := storage.GetBookmarks(ctx, id)
 		if err != nil {
-			// TODO(thinkofher) output json error message
-			w.WriteHeader(http.StatusNotFound)
+			WriteError(w, ErrSyncNotFound)
 			return
 		}
 
@@ -387,3 +458,31 @@ func Version(storage BookmarksStorage, params QueryParameters) http.HandlerFunc
 		})
 	})
 }
+
+// DeleteBookmarks removes the bookmark sync corresponding to the
+// provided sync ID, for clients resetting their sync.
+//
+//	DELETE /bookmarks/{id}
+//
+// Query params:
+//
+// * id: 32 character alphanumeric sync ID.
+//
+// On success the response has no body.
+func DeleteBookmarks(storage BookmarksStorage, params QueryParameters) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := params.ID(r)
+		if id == "" {
+			WriteError(w, ErrRequiredDataNotFound)
+			return
+		}
+
+		if err := storage.DeleteBookmarks(ctx, id); err != nil {
+			WriteError(w, ErrUnspecified)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
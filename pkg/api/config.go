@@ -0,0 +1,48 @@
+package api
+
+// Service status codes reported by Info and recognized by xBrowserSync
+// clients.
+const (
+	// StatusOnline means the service is fully operational.
+	StatusOnline = 1
+	// StatusOffline means the service is unavailable.
+	StatusOffline = 2
+	// StatusNotAcceptingNewSyncs means the service still serves existing
+	// syncs but refuses to create new ones.
+	StatusNotAcceptingNewSyncs = 3
+)
+
+// Config holds the server-wide tunables that control the behaviour of the
+// xBrowserSync compatible API exposed by this package.
+type Config struct {
+	// MaxSyncSize is the maximum size (in bytes) of the request body
+	// accepted by CreateBookmarks and UpdateBookmarks, JSON envelope
+	// included. A value of zero disables the check.
+	MaxSyncSize int64
+
+	// Status is the xBrowserSync service status code, as described by
+	// Info. It also gates CreateBookmarks: StatusNotAcceptingNewSyncs
+	// causes new sync creation to be refused.
+	Status int
+
+	// DailyNewSyncsLimit caps how many new syncs a single client IP may
+	// create within a single UTC calendar day. A value of zero disables
+	// the check.
+	DailyNewSyncsLimit int
+
+	// TrustProxy makes the daily-new-syncs limit key on the left-most
+	// address in the X-Forwarded-For header instead of the connection's
+	// RemoteAddr. Only enable this when lalyta sits behind a reverse
+	// proxy that overwrites any client-supplied X-Forwarded-For, or a
+	// direct client can spoof the header to dodge the limit entirely.
+	TrustProxy bool
+}
+
+// DefaultConfig returns the Config matching the previous hardcoded
+// behaviour of this package: service online, 200KB syncs, no daily limit.
+func DefaultConfig() Config {
+	return Config{
+		MaxSyncSize: 204800,
+		Status:      StatusOnline,
+	}
+}
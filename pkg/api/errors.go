@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/alioygur/gores"
+)
+
+// Error is the structured JSON error body returned to xBrowserSync clients.
+// Handlers never construct an Error by hand; they return one of the
+// canonical exceptions declared below via WriteError.
+type Error struct {
+	HTTPStatus int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Canonical exceptions recognized by the official xBrowserSync browser
+// extensions. Their Code values must match the upstream service exactly so
+// that clients can render a human-readable message instead of failing
+// silently.
+var (
+	ErrUnspecified = Error{
+		HTTPStatus: http.StatusInternalServerError,
+		Code:       "UnspecifiedException",
+		Message:    "an unspecified error has occurred",
+	}
+	ErrRequiredDataNotFound = Error{
+		HTTPStatus: http.StatusBadRequest,
+		Code:       "RequiredDataNotFoundException",
+		Message:    "required data was not found in the request",
+	}
+	ErrSyncNotFound = Error{
+		HTTPStatus: http.StatusNotFound,
+		Code:       "SyncNotFoundException",
+		Message:    "sync with given id could not be found",
+	}
+	ErrSyncDataNotFound = Error{
+		HTTPStatus: http.StatusNotFound,
+		Code:       "SyncDataNotFoundException",
+		Message:    "sync data for given id could not be found",
+	}
+	ErrSyncConflict = Error{
+		HTTPStatus: http.StatusConflict,
+		Code:       "SyncConflictException",
+		Message:    "sync data conflict, lastUpdated value is outdated",
+	}
+	ErrSyncDataLimitExceeded = Error{
+		HTTPStatus: http.StatusRequestEntityTooLarge,
+		Code:       "SyncDataLimitExceededException",
+		Message:    "sync data exceeds the maximum size allowed by the service",
+	}
+	ErrNewSyncsForbidden = Error{
+		HTTPStatus: http.StatusForbidden,
+		Code:       "NewSyncsForbiddenException",
+		Message:    "the service is not currently accepting new syncs",
+	}
+	ErrNewSyncsLimitExceeded = Error{
+		HTTPStatus: http.StatusTooManyRequests,
+		Code:       "NewSyncsLimitExceededException",
+		Message:    "the daily limit of new syncs has been reached",
+	}
+	ErrUnauthorized = Error{
+		HTTPStatus: http.StatusUnauthorized,
+		Code:       "UnauthorizedException",
+		Message:    "a valid bearer token is required for this request",
+	}
+	ErrTooManyRequests = Error{
+		HTTPStatus: http.StatusTooManyRequests,
+		Code:       "TooManyRequestsException",
+		Message:    "too many requests for this sync, please slow down",
+	}
+)
+
+// WriteError renders err as the JSON body xBrowserSync clients expect,
+// using its HTTPStatus as the response status code. Middleware living
+// outside this package (auth, rate limiting) also uses it so that every
+// rejection looks the same to clients.
+func WriteError(w http.ResponseWriter, err Error) {
+	gores.JSON(w, err.HTTPStatus, &err)
+}
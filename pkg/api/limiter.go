@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dailySyncCounter tracks how many new syncs each client IP has created
+// during the current UTC day, resetting the tally at UTC midnight.
+type dailySyncCounter struct {
+	mu     sync.Mutex
+	day    time.Time
+	counts map[string]int
+}
+
+func newDailySyncCounter() *dailySyncCounter {
+	return &dailySyncCounter{
+		day:    utcDay(time.Now()),
+		counts: make(map[string]int),
+	}
+}
+
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// allow reports whether ip is allowed to create another sync today given
+// limit, recording the attempt when it is. A limit of zero always allows.
+func (c *dailySyncCounter) allow(ip string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if today := utcDay(time.Now()); today.After(c.day) {
+		c.day = today
+		c.counts = make(map[string]int)
+	}
+
+	if c.counts[ip] >= limit {
+		return false
+	}
+	c.counts[ip]++
+	return true
+}
+
+// release gives back a slot reserved by allow, for callers that reserved
+// one optimistically but failed to actually create the sync. It is a
+// no-op once the day has already rolled over past the reservation.
+func (c *dailySyncCounter) release(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if today := utcDay(time.Now()); today.After(c.day) {
+		return
+	}
+
+	if c.counts[ip] > 0 {
+		c.counts[ip]--
+	}
+}
+
+// clientIP extracts the requesting client's IP address from r. When
+// trustProxy is true, it prefers the left-most address in
+// X-Forwarded-For, falling back to RemoteAddr (split into host and port)
+// when the header is absent. trustProxy must only be set when a trusted
+// reverse proxy overwrites any client-supplied X-Forwarded-For; otherwise
+// a direct client can spoof it to dodge the limit.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			if ip := strings.TrimSpace(strings.SplitN(xff, ",", 2)[0]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
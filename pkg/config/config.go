@@ -0,0 +1,147 @@
+// Package config loads lalyta's runtime configuration from an optional
+// TOML file plus environment variable overrides, so operators can run
+// the server in Docker or systemd without recompiling it.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config holds the runtime knobs cmd/lalyta reads from the config file and
+// the environment. Rate limiting and retention are set by command-line
+// flags instead and are not part of it.
+type Config struct {
+	ListenAddr string `toml:"listen_addr" json:"listen_addr"`
+
+	DB struct {
+		Driver string `toml:"driver" json:"driver"`
+		DSN    string `toml:"dsn" json:"dsn"`
+	} `toml:"db" json:"db"`
+
+	Service struct {
+		Status             int    `toml:"status" json:"status"`
+		Message            string `toml:"message" json:"message"`
+		Location           string `toml:"location" json:"location"`
+		MaxSyncSize        int64  `toml:"max_sync_size" json:"max_sync_size"`
+		DailyNewSyncsLimit int    `toml:"daily_new_syncs_limit" json:"daily_new_syncs_limit"`
+		TrustProxy         bool   `toml:"trust_proxy" json:"trust_proxy"`
+	} `toml:"service" json:"service"`
+
+	Auth struct {
+		Mode      string `toml:"mode" json:"mode"`
+		Token     string `toml:"token" json:"token"`
+		JWTSecret string `toml:"jwt_secret" json:"jwt_secret"`
+	} `toml:"auth" json:"auth"`
+
+	TLS struct {
+		CertFile string `toml:"cert_file" json:"cert_file"`
+		KeyFile  string `toml:"key_file" json:"key_file"`
+	} `toml:"tls" json:"tls"`
+
+	Log struct {
+		Format string `toml:"format" json:"format"`
+	} `toml:"log" json:"log"`
+}
+
+// Default returns the Config used when no file is supplied and no
+// environment overrides are set, matching lalyta's previous hardcoded
+// behaviour.
+func Default() Config {
+	cfg := Config{ListenAddr: "0.0.0.0:8080"}
+	cfg.DB.Driver = "bunt"
+	cfg.DB.DSN = "lalyta.db"
+	cfg.Service.Status = 1
+	cfg.Service.Message = "Hello World!"
+	cfg.Service.Location = "PL"
+	cfg.Service.MaxSyncSize = 204800
+	cfg.Log.Format = "text"
+	return cfg
+}
+
+// Load builds the effective Config: it starts from Default, applies the
+// TOML file at path if path is non-empty, and finally applies any
+// LALYTA_* environment overrides on top.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if _, err := toml.DecodeFile(path, &cfg); err != nil {
+			return Config{}, fmt.Errorf("toml.DecodeFile: %w", err)
+		}
+	}
+
+	applyEnv(&cfg)
+	return cfg, nil
+}
+
+func applyEnv(cfg *Config) {
+	str(&cfg.ListenAddr, "LALYTA_LISTEN_ADDR")
+	str(&cfg.DB.Driver, "LALYTA_DB_DRIVER")
+	str(&cfg.DB.DSN, "LALYTA_DB_DSN")
+	intEnv(&cfg.Service.Status, "LALYTA_SERVICE_STATUS")
+	str(&cfg.Service.Message, "LALYTA_SERVICE_MESSAGE")
+	str(&cfg.Service.Location, "LALYTA_SERVICE_LOCATION")
+	int64Env(&cfg.Service.MaxSyncSize, "LALYTA_SERVICE_MAX_SYNC_SIZE")
+	intEnv(&cfg.Service.DailyNewSyncsLimit, "LALYTA_SERVICE_DAILY_NEW_SYNCS_LIMIT")
+	boolEnv(&cfg.Service.TrustProxy, "LALYTA_SERVICE_TRUST_PROXY")
+	str(&cfg.Auth.Mode, "LALYTA_AUTH_MODE")
+	str(&cfg.Auth.Token, "LALYTA_AUTH_TOKEN")
+	str(&cfg.Auth.JWTSecret, "LALYTA_AUTH_JWT_SECRET")
+	str(&cfg.TLS.CertFile, "LALYTA_TLS_CERT_FILE")
+	str(&cfg.TLS.KeyFile, "LALYTA_TLS_KEY_FILE")
+	str(&cfg.Log.Format, "LALYTA_LOG_FORMAT")
+}
+
+func str(dst *string, env string) {
+	if v, ok := os.LookupEnv(env); ok {
+		*dst = v
+	}
+}
+
+func intEnv(dst *int, env string) {
+	if v, ok := os.LookupEnv(env); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			*dst = n
+		}
+	}
+}
+
+func int64Env(dst *int64, env string) {
+	if v, ok := os.LookupEnv(env); ok {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			*dst = n
+		}
+	}
+}
+
+func boolEnv(dst *bool, env string) {
+	if v, ok := os.LookupEnv(env); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			*dst = b
+		}
+	}
+}
+
+// String renders cfg for diagnostic startup logging, redacting secrets.
+func (cfg Config) String() string {
+	redacted := cfg
+	if redacted.Auth.Token != "" {
+		redacted.Auth.Token = "(redacted)"
+	}
+	if redacted.Auth.JWTSecret != "" {
+		redacted.Auth.JWTSecret = "(redacted)"
+	}
+
+	if redacted.Log.Format == "json" {
+		b, err := json.Marshal(redacted)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%+v", redacted)
+}
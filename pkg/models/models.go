@@ -7,6 +7,11 @@ type Bookmarks struct {
 	Bookmarks   string    `json:"bookmarks"`
 	LastUpdated time.Time `json:"lastUpdated"`
 	Version     string    `json:"version"`
+
+	// CreatedAt is when the sync was first created, distinct from
+	// LastUpdated which changes on every update. Storage backends that
+	// predate this field default it to LastUpdated on first migration.
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 func (b Bookmarks) Empty() bool {
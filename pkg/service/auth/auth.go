@@ -0,0 +1,173 @@
+// Package auth implements optional authentication for the xBrowserSync
+// compatible API: a server-wide bearer token, or a per-sync mode where
+// CreateBookmarks issues a short-lived JWT that must be presented on
+// subsequent requests for that sync.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+)
+
+// Mode selects how lalyta authenticates requests.
+type Mode string
+
+const (
+	// ModeNone disables authentication. It is the zero value.
+	ModeNone Mode = ""
+	// ModeToken requires a single server-wide bearer token on every
+	// mutating endpoint.
+	ModeToken Mode = "token"
+	// ModeJWT has CreateBookmarks issue a short-lived, sync-scoped JWT
+	// that must be presented as a bearer token on subsequent requests
+	// for that sync.
+	ModeJWT Mode = "jwt"
+)
+
+const defaultTokenTTL = time.Hour
+
+// Config configures the auth package's behaviour.
+type Config struct {
+	// Mode selects the authentication scheme.
+	Mode Mode
+
+	// Token is the server-wide bearer token required by ModeToken.
+	Token string
+
+	// Secret signs and verifies the JWTs issued in ModeJWT.
+	Secret []byte
+
+	// TokenTTL is how long a JWT issued in ModeJWT remains valid. It
+	// defaults to one hour when zero.
+	TokenTTL time.Duration
+}
+
+func (cfg Config) ttl() time.Duration {
+	if cfg.TokenTTL <= 0 {
+		return defaultTokenTTL
+	}
+	return cfg.TokenTTL
+}
+
+// errUnauthenticated is returned by verifySyncToken when a token fails to
+// parse, is not validly signed, has expired or is scoped to another sync.
+var errUnauthenticated = errors.New("auth: missing or invalid bearer token")
+
+// IssueSyncToken returns a short-lived JWT authorizing further access to
+// the sync identified by id. It is only meaningful in ModeJWT.
+func IssueSyncToken(cfg Config, id string) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   id,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(cfg.ttl())),
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(cfg.Secret)
+	if err != nil {
+		return "", fmt.Errorf("token.SignedString: %w", err)
+	}
+	return signed, nil
+}
+
+func verifySyncToken(cfg Config, token, id string) error {
+	claims := new(jwt.RegisteredClaims)
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Method)
+		}
+		return cfg.Secret, nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	if err != nil || !parsed.Valid || claims.Subject != id {
+		return errUnauthenticated
+	}
+	return nil
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(h, prefix), true
+}
+
+// Issuer issues short-lived per-sync JWTs according to cfg. It implements
+// api.TokenIssuer.
+type Issuer struct {
+	cfg Config
+}
+
+// NewIssuer returns an Issuer that signs tokens with cfg.
+func NewIssuer(cfg Config) *Issuer {
+	return &Issuer{cfg: cfg}
+}
+
+func (i *Issuer) IssueToken(id string) (string, error) {
+	return IssueSyncToken(i.cfg, id)
+}
+
+// Middleware enforces cfg's authentication mode on a route that already
+// has a sync ID in its path, using params to extract it. ModeNone lets
+// every request through unchanged. It is not meant for CreateBookmarks:
+// use BootstrapMiddleware there instead, since ModeJWT has no token to
+// verify yet on the route that issues it.
+func Middleware(cfg Config, params api.QueryParameters) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Mode == ModeNone {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				api.WriteError(w, api.ErrUnauthorized)
+				return
+			}
+
+			switch cfg.Mode {
+			case ModeToken:
+				if token != cfg.Token {
+					api.WriteError(w, api.ErrUnauthorized)
+					return
+				}
+			case ModeJWT:
+				if id := params.ID(r); id == "" || verifySyncToken(cfg, token, id) != nil {
+					api.WriteError(w, api.ErrUnauthorized)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BootstrapMiddleware enforces cfg's authentication mode on the route that
+// creates new syncs. ModeJWT has nothing to verify at creation time (the
+// handler is what issues the token), so it lets the request through
+// unchanged; ModeToken still requires the server-wide token.
+func BootstrapMiddleware(cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Mode != ModeToken {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok || token != cfg.Token {
+				api.WriteError(w, api.ErrUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
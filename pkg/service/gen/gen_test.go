@@ -0,0 +1,57 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	for _, length := range []int{0, 1, 8, 32, 128} {
+		s, err := String(length)
+		if err != nil {
+			t.Fatalf("String(%d): %v", length, err)
+		}
+		if len(s) != length {
+			t.Fatalf("String(%d) returned length %d", length, len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(letters, c) {
+				t.Fatalf("String(%d) = %q contains unexpected character %q", length, s, c)
+			}
+		}
+	}
+}
+
+func FuzzString(f *testing.F) {
+	f.Add(32)
+	f.Add(1)
+	f.Add(0)
+
+	f.Fuzz(func(t *testing.T, length int) {
+		if length < 0 || length > 4096 {
+			t.Skip()
+		}
+
+		s, err := String(length)
+		if err != nil {
+			t.Fatalf("String(%d): %v", length, err)
+		}
+		if len(s) != length {
+			t.Fatalf("String(%d) returned length %d", length, len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(letters, c) {
+				t.Fatalf("String(%d) = %q contains unexpected character %q", length, s, c)
+			}
+		}
+	})
+}
+
+func BenchmarkString(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := String(32); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
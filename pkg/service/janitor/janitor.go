@@ -0,0 +1,113 @@
+// Package janitor implements a background sweep that deletes syncs older
+// than a configured retention period.
+package janitor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+)
+
+// Basis selects which timestamp retention is measured against.
+type Basis string
+
+const (
+	// BasisCreatedAt measures age from when the sync was first created.
+	BasisCreatedAt Basis = "created_at"
+	// BasisLastUpdated measures age from the sync's last update.
+	BasisLastUpdated Basis = "last_updated"
+)
+
+const defaultInterval = time.Hour
+
+// Config configures the janitor's retention sweep.
+type Config struct {
+	// RetentionDays is how many days old a sync's Basis timestamp may get
+	// before the sync is deleted. A value of zero disables the sweep,
+	// keeping every sync forever.
+	RetentionDays int
+
+	// Basis selects which timestamp retention is measured against. It
+	// defaults to BasisLastUpdated when empty.
+	Basis Basis
+
+	// Interval is how often the janitor scans storage. It defaults to
+	// one hour when zero.
+	Interval time.Duration
+
+	// DryRun logs what would be deleted instead of deleting it.
+	DryRun bool
+}
+
+func (cfg Config) basis() Basis {
+	if cfg.Basis == "" {
+		return BasisLastUpdated
+	}
+	return cfg.Basis
+}
+
+func (cfg Config) interval() time.Duration {
+	if cfg.Interval <= 0 {
+		return defaultInterval
+	}
+	return cfg.Interval
+}
+
+// Run sweeps storage for stale syncs every cfg.Interval until ctx is
+// cancelled. It runs an initial sweep immediately and is meant to be
+// started in its own goroutine. A RetentionDays of zero makes Run return
+// immediately without ever sweeping.
+func Run(ctx context.Context, storage api.BookmarksStorage, cfg Config) {
+	if cfg.RetentionDays <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.interval())
+	defer ticker.Stop()
+
+	for {
+		sweep(ctx, storage, cfg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sweep(ctx context.Context, storage api.BookmarksStorage, cfg Config) {
+	all, err := storage.ListBookmarks(ctx)
+	if err != nil {
+		log.Printf("janitor: ListBookmarks: %v", err)
+		return
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -cfg.RetentionDays)
+	basis := cfg.basis()
+
+	for _, b := range all {
+		ts := b.LastUpdated
+		if basis == BasisCreatedAt {
+			ts = b.CreatedAt
+		}
+		if ts.After(cutoff) {
+			continue
+		}
+
+		if cfg.DryRun {
+			log.Printf("janitor: dry-run: would delete sync %s (%s %s is older than %d days)",
+				b.ID, basis, ts, cfg.RetentionDays)
+			continue
+		}
+
+		if err := storage.DeleteBookmarks(ctx, b.ID); err != nil {
+			log.Printf("janitor: DeleteBookmarks(%s): %v", b.ID, err)
+			continue
+		}
+		log.Printf("janitor: deleted sync %s (%s %s is older than %d days)",
+			b.ID, basis, ts, cfg.RetentionDays)
+	}
+}
@@ -0,0 +1,143 @@
+// Package ratelimit implements a token-bucket rate limiter keyed by sync
+// ID and client IP, used to throttle endpoints such as UpdateBookmarks
+// and Bookmarks against abusive clients.
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+)
+
+// Config configures a Limiter.
+type Config struct {
+	// RatePerSecond is the sustained number of requests allowed per
+	// sync ID and client IP pair. A value of zero disables the limiter.
+	RatePerSecond float64
+
+	// Burst is the maximum number of requests allowed in a single
+	// burst. It defaults to 1 when zero.
+	Burst int
+
+	// TrustProxy makes the limiter key on the left-most address in the
+	// X-Forwarded-For header instead of the connection's RemoteAddr.
+	// Only enable this when lalyta sits behind a reverse proxy that
+	// overwrites any client-supplied X-Forwarded-For, or a direct
+	// client can spoof the header to merge everyone's buckets.
+	TrustProxy bool
+}
+
+func (cfg Config) burst() int {
+	if cfg.Burst <= 0 {
+		return 1
+	}
+	return cfg.Burst
+}
+
+// bucketTTL is how long a key's bucket is kept after its last request
+// before it is evicted, so that an endless stream of distinct IDs/IPs
+// doesn't grow buckets without bound.
+const bucketTTL = 10 * time.Minute
+
+// sweepInterval bounds how often evictions run, so a busy limiter isn't
+// scanning the whole map on every request.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter throttles requests per sync ID and client IP, using one token
+// bucket per key.
+type Limiter struct {
+	cfg       Config
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New returns a Limiter configured by cfg.
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		cfg:     cfg,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *Limiter) allow(key string) bool {
+	if l.cfg.RatePerSecond <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.evictStale(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.cfg.RatePerSecond), l.cfg.burst())}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter.Allow()
+}
+
+// evictStale drops buckets untouched for longer than bucketTTL. It must be
+// called with l.mu held, and only scans the map once per sweepInterval.
+func (l *Limiter) evictStale(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > bucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP extracts the requesting client's IP address from r. When
+// trustProxy is true, it prefers the left-most address in
+// X-Forwarded-For, falling back to RemoteAddr (split into host and port)
+// when the header is absent.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			if ip := strings.TrimSpace(strings.SplitN(xff, ",", 2)[0]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Middleware throttles requests to the sync identified by params' ID and
+// the caller's IP, writing a TooManyRequestsException when the rate is
+// exceeded.
+func (l *Limiter) Middleware(params api.QueryParameters) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := params.ID(r) + ":" + clientIP(r, l.cfg.TrustProxy)
+			if !l.allow(key) {
+				api.WriteError(w, api.ErrTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
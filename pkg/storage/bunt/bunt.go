@@ -0,0 +1,191 @@
+// Package bunt implements api.BookmarksStorage on top of the embedded
+// buntdb engine. It is the default backend used when no other driver is
+// configured, requiring no external database to run lalyta.
+package bunt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/buntdb"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+	"github.com/thinkofher/lalyta/pkg/models"
+)
+
+type DB struct {
+	bunt *buntdb.DB
+}
+
+// Open opens (creating if necessary) the buntdb database file at path and
+// prepares it for use as a BookmarksStorage backend.
+func Open(path string) (*DB, error) {
+	b, err := buntdb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("buntdb.Open: %w", err)
+	}
+	return New(b), nil
+}
+
+// New wraps an already open buntdb database as a BookmarksStorage backend.
+func New(b *buntdb.DB) *DB {
+	b.CreateIndex("bookmarks", "bookmarks:*", buntdb.IndexJSON("id"))
+	db := &DB{bunt: b}
+	db.migrateCreatedAt()
+	return db
+}
+
+// Close closes the underlying buntdb database.
+func (db *DB) Close() error {
+	return db.bunt.Close()
+}
+
+type bookmarksEntry struct {
+	ID          string    `json:"id"`
+	Bookmarks   string    `json:"bookmarks"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	Version     string    `json:"version"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func bookmarksKey(id string) string {
+	return fmt.Sprintf("bookmarks:%s", id)
+}
+
+func makeBookmarksEntry(b bookmarksEntry) (string, string) {
+	val, err := json.Marshal(b)
+	if err != nil {
+		return "", ""
+	}
+	return bookmarksKey(b.ID), string(val)
+}
+
+// migrateCreatedAt backfills CreatedAt on rows written before that field
+// existed, defaulting it to LastUpdated since the real creation time was
+// never recorded.
+func (db *DB) migrateCreatedAt() {
+	var stale []bookmarksEntry
+
+	db.bunt.View(func(tx *buntdb.Tx) error {
+		return tx.Ascend("bookmarks", func(key, val string) bool {
+			e := new(bookmarksEntry)
+			if err := json.Unmarshal([]byte(val), e); err != nil {
+				return true
+			}
+			if e.CreatedAt.IsZero() {
+				stale = append(stale, *e)
+			}
+			return true
+		})
+	})
+
+	for _, e := range stale {
+		e.CreatedAt = e.LastUpdated
+		db.bunt.Update(func(tx *buntdb.Tx) error {
+			key, value := makeBookmarksEntry(e)
+			_, _, err := tx.Set(key, value, nil)
+			return err
+		})
+	}
+}
+
+func (db *DB) SetBookmarks(ctx context.Context, b models.Bookmarks) error {
+	return db.bunt.Update(func(tx *buntdb.Tx) error {
+		key, value := makeBookmarksEntry(bookmarksEntry{
+			ID:          b.ID,
+			Bookmarks:   b.Bookmarks,
+			LastUpdated: b.LastUpdated,
+			Version:     b.Version,
+			CreatedAt:   b.CreatedAt,
+		})
+
+		_, _, err := tx.Set(key, value, nil)
+		if err != nil {
+			return fmt.Errorf("tx.Set: %w", err)
+		}
+		return nil
+	})
+}
+
+func (db *DB) GetBookmarks(ctx context.Context, id string) (*models.Bookmarks, error) {
+	res := new(models.Bookmarks)
+
+	err := db.bunt.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(bookmarksKey(id))
+		if err != nil {
+			return fmt.Errorf("tx.Get: %w", err)
+		}
+
+		b := new(bookmarksEntry)
+
+		err = json.Unmarshal([]byte(val), b)
+		if err != nil {
+			return fmt.Errorf("json.Unmarshal: %w", err)
+		}
+
+		res = &models.Bookmarks{
+			ID:          b.ID,
+			Bookmarks:   b.Bookmarks,
+			LastUpdated: b.LastUpdated,
+			Version:     b.Version,
+			CreatedAt:   b.CreatedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db.bunt.View: %w", err)
+	}
+	if res.Empty() {
+		return nil, api.ErrBookmarksNotFound
+	}
+
+	return res, nil
+}
+
+func (db *DB) DeleteBookmarks(ctx context.Context, id string) error {
+	err := db.bunt.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(bookmarksKey(id))
+		if err != nil && err != buntdb.ErrNotFound {
+			return fmt.Errorf("tx.Delete: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("db.bunt.Update: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ListBookmarks(ctx context.Context) ([]models.Bookmarks, error) {
+	var all []models.Bookmarks
+
+	err := db.bunt.View(func(tx *buntdb.Tx) error {
+		return tx.Ascend("bookmarks", func(key, val string) bool {
+			if !strings.HasPrefix(key, "bookmarks:") {
+				return true
+			}
+
+			e := new(bookmarksEntry)
+			if err := json.Unmarshal([]byte(val), e); err != nil {
+				return true
+			}
+
+			all = append(all, models.Bookmarks{
+				ID:          e.ID,
+				Bookmarks:   e.Bookmarks,
+				LastUpdated: e.LastUpdated,
+				Version:     e.Version,
+				CreatedAt:   e.CreatedAt,
+			})
+			return true
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db.bunt.View: %w", err)
+	}
+
+	return all, nil
+}
@@ -0,0 +1,67 @@
+// Package memory implements api.BookmarksStorage entirely in process
+// memory. It keeps no data on disk, making it useful for tests and
+// ephemeral deployments where persistence is not required.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+	"github.com/thinkofher/lalyta/pkg/models"
+)
+
+type DB struct {
+	mu    sync.RWMutex
+	store map[string]models.Bookmarks
+}
+
+// New returns an empty in-memory BookmarksStorage backend.
+func New() *DB {
+	return &DB{
+		store: make(map[string]models.Bookmarks),
+	}
+}
+
+// Close is a no-op, satisfying storage.Storage.
+func (db *DB) Close() error {
+	return nil
+}
+
+func (db *DB) SetBookmarks(ctx context.Context, b models.Bookmarks) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.store[b.ID] = b
+	return nil
+}
+
+func (db *DB) GetBookmarks(ctx context.Context, id string) (*models.Bookmarks, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	b, ok := db.store[id]
+	if !ok {
+		return nil, api.ErrBookmarksNotFound
+	}
+	return &b, nil
+}
+
+func (db *DB) DeleteBookmarks(ctx context.Context, id string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delete(db.store, id)
+	return nil
+}
+
+func (db *DB) ListBookmarks(ctx context.Context) ([]models.Bookmarks, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	all := make([]models.Bookmarks, 0, len(db.store))
+	for _, b := range db.store {
+		all = append(all, b)
+	}
+	return all, nil
+}
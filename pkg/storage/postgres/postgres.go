@@ -0,0 +1,117 @@
+// Package postgres implements api.BookmarksStorage on top of PostgreSQL,
+// for operators who want to run lalyta against an existing Postgres
+// cluster rather than the embedded buntdb file.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/lib/pq"
+
+	"github.com/thinkofher/lalyta/pkg/api"
+	"github.com/thinkofher/lalyta/pkg/models"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS bookmarks (
+	id VARCHAR(32) PRIMARY KEY,
+	bookmarks TEXT NOT NULL,
+	version VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	modified_at TIMESTAMPTZ NOT NULL
+);
+`
+
+type DB struct {
+	sql *sql.DB
+}
+
+// Open connects to the PostgreSQL database identified by dsn and
+// migrates its schema, creating the bookmarks table if necessary.
+func Open(dsn string) (*DB, error) {
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("sqlDB.Ping: %w", err)
+	}
+
+	if _, err := sqlDB.Exec(schema); err != nil {
+		return nil, fmt.Errorf("migrate schema: %w", err)
+	}
+
+	return &DB{sql: sqlDB}, nil
+}
+
+// Close closes the underlying database connection pool.
+func (db *DB) Close() error {
+	return db.sql.Close()
+}
+
+func (db *DB) SetBookmarks(ctx context.Context, b models.Bookmarks) error {
+	_, err := db.sql.ExecContext(ctx, `
+		INSERT INTO bookmarks (id, bookmarks, version, created_at, modified_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			bookmarks = EXCLUDED.bookmarks,
+			version = EXCLUDED.version,
+			modified_at = EXCLUDED.modified_at
+	`, b.ID, b.Bookmarks, b.Version, b.CreatedAt, b.LastUpdated)
+	if err != nil {
+		return fmt.Errorf("db.sql.ExecContext: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetBookmarks(ctx context.Context, id string) (*models.Bookmarks, error) {
+	row := db.sql.QueryRowContext(ctx,
+		`SELECT bookmarks, version, created_at, modified_at FROM bookmarks WHERE id = $1`, id)
+
+	res := &models.Bookmarks{ID: id}
+	if err := row.Scan(&res.Bookmarks, &res.Version, &res.CreatedAt, &res.LastUpdated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, api.ErrBookmarksNotFound
+		}
+		return nil, fmt.Errorf("row.Scan: %w", err)
+	}
+
+	return res, nil
+}
+
+func (db *DB) DeleteBookmarks(ctx context.Context, id string) error {
+	if _, err := db.sql.ExecContext(ctx, `DELETE FROM bookmarks WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("db.sql.ExecContext: %w", err)
+	}
+	return nil
+}
+
+// ListBookmarks is meant for maintenance tasks such as retention sweeps,
+// which only need identifiers and timestamps, not the full encrypted
+// payload, so the returned entries leave Bookmarks and Version unset.
+func (db *DB) ListBookmarks(ctx context.Context) ([]models.Bookmarks, error) {
+	rows, err := db.sql.QueryContext(ctx,
+		`SELECT id, created_at, modified_at FROM bookmarks`)
+	if err != nil {
+		return nil, fmt.Errorf("db.sql.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var all []models.Bookmarks
+	for rows.Next() {
+		var b models.Bookmarks
+		if err := rows.Scan(&b.ID, &b.CreatedAt, &b.LastUpdated); err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		all = append(all, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return all, nil
+}
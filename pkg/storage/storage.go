@@ -1,93 +1,64 @@
+// Package storage selects and constructs the BookmarksStorage backend
+// used by the server. The actual implementations live in subpackages
+// (bunt, postgres, mysql, memory); this package only wires a Config to
+// the right one.
 package storage
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/thinkofher/lalyta/pkg/api"
-	"github.com/thinkofher/lalyta/pkg/models"
-	"github.com/tidwall/buntdb"
+	"github.com/thinkofher/lalyta/pkg/storage/bunt"
+	"github.com/thinkofher/lalyta/pkg/storage/memory"
+	"github.com/thinkofher/lalyta/pkg/storage/mysql"
+	"github.com/thinkofher/lalyta/pkg/storage/postgres"
 )
 
-type DB struct {
-	bunt *buntdb.DB
-}
+// Driver identifies a BookmarksStorage backend implementation.
+type Driver string
 
-func New(b *buntdb.DB) *DB {
-	b.CreateIndex("bookmarks", "bookmarks:*", buntdb.IndexJSON("id"))
-	return &DB{
-		bunt: b,
-	}
-}
+const (
+	// Bunt stores syncs in a local buntdb file. It is the default and
+	// requires no external database.
+	Bunt Driver = "bunt"
+	// Postgres stores syncs in a PostgreSQL database.
+	Postgres Driver = "postgres"
+	// MySQL stores syncs in a MySQL or MariaDB database.
+	MySQL Driver = "mysql"
+	// Memory stores syncs in process memory only.
+	Memory Driver = "memory"
+)
 
-type bookmarksEntry struct {
-	ID          string    `json:"id"`
-	Bookmarks   string    `json:"bookmarks"`
-	LastUpdated time.Time `json:"lastUpdated"`
-	Version     string    `json:"version"`
-}
+// Config selects and configures a BookmarksStorage backend.
+type Config struct {
+	// Driver selects the backend implementation. The zero value
+	// defaults to Bunt.
+	Driver Driver
 
-func bookmarksKey(id string) string {
-	return fmt.Sprintf("bookmarks:%s", id)
+	// DSN is the backend-specific data source: a file path for Bunt, or
+	// a connection string for Postgres/MySQL. Unused by Memory.
+	DSN string
 }
 
-func makeBookmarksEntry(b bookmarksEntry) (string, string) {
-	val, err := json.Marshal(b)
-	if err != nil {
-		return "", ""
-	}
-	return bookmarksKey(b.ID), string(val)
+// Storage is a BookmarksStorage backend that also owns a closable
+// resource, such as an open file handle or database connection.
+type Storage interface {
+	api.BookmarksStorage
+	Close() error
 }
 
-func (db *DB) SetBookmarks(ctx context.Context, b models.Bookmarks) error {
-	return db.bunt.Update(func(tx *buntdb.Tx) error {
-		key, value := makeBookmarksEntry(bookmarksEntry{
-			ID:          b.ID,
-			Bookmarks:   b.Bookmarks,
-			LastUpdated: b.LastUpdated,
-			Version:     b.Version,
-		})
-
-		_, _, err := tx.Set(key, value, nil)
-		if err != nil {
-			return fmt.Errorf("tx.Set: %w", err)
-		}
-		return nil
-	})
-}
-
-func (db *DB) GetBookmarks(ctx context.Context, id string) (*models.Bookmarks, error) {
-	res := new(models.Bookmarks)
-
-	err := db.bunt.View(func(tx *buntdb.Tx) error {
-		val, err := tx.Get(bookmarksKey(id))
-		if err != nil {
-			return fmt.Errorf("tx.Get: %w", err)
-		}
-
-		b := new(bookmarksEntry)
-
-		err = json.Unmarshal([]byte(val), b)
-		if err != nil {
-			return fmt.Errorf("json.Unmarshal: %w", err)
-		}
-
-		res = &models.Bookmarks{
-			ID:          b.ID,
-			Bookmarks:   b.Bookmarks,
-			LastUpdated: b.LastUpdated,
-			Version:     b.Version,
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("db.bunt.View: %w", err)
-	}
-	if res.Empty() {
-		return nil, api.ErrBookmarksNotFound
+// New constructs the BookmarksStorage backend selected by cfg.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case Postgres:
+		return postgres.Open(cfg.DSN)
+	case MySQL:
+		return mysql.Open(cfg.DSN)
+	case Memory:
+		return memory.New(), nil
+	case Bunt, "":
+		return bunt.Open(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Driver)
 	}
-
-	return res, nil
 }